@@ -0,0 +1,90 @@
+// Copyright (c) 2015, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package xurls
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// htmlOpaqueTags are elements whose text content should never be
+// autolinked: it is either already rendered as a link (<a>), not meant to
+// be parsed as prose (<code>, <pre>), or not text at all (<script>,
+// <style>).
+var htmlOpaqueTags = map[string]bool{
+	"a":      true,
+	"code":   true,
+	"pre":    true,
+	"script": true,
+	"style":  true,
+}
+
+// FindInHTML returns the matches of re found in src, skipping tags,
+// attribute values, and the text content of elements in htmlOpaqueTags
+// (<a>, <code>, <pre>, <script> and <style>). Byte offsets in the returned
+// Matches refer to src. This uses a small hand-rolled tag-state scanner
+// rather than a full HTML parser, so it can be fooled by sufficiently
+// exotic markup.
+func FindInHTML(src []byte, re *regexp.Regexp) []Match {
+	return findMasked(src, re, maskHTML(src))
+}
+
+func maskHTML(src []byte) []bool {
+	masked := make([]bool, len(src))
+	mark := func(lo, hi int) {
+		for ; lo < hi; lo++ {
+			masked[lo] = true
+		}
+	}
+
+	var opaque []string // stack of open htmlOpaqueTags elements
+	for i := 0; i < len(src); {
+		if src[i] != '<' {
+			if len(opaque) > 0 {
+				masked[i] = true
+			}
+			i++
+			continue
+		}
+
+		end := bytes.IndexByte(src[i:], '>')
+		if end < 0 {
+			mark(i, len(src))
+			break
+		}
+		end += i + 1 // exclusive end of the tag, past '>'
+		mark(i, end)
+
+		name, closing := tagName(src[i:end])
+		switch {
+		case closing:
+			if len(opaque) > 0 && opaque[len(opaque)-1] == name {
+				opaque = opaque[:len(opaque)-1]
+			}
+		case htmlOpaqueTags[name] && !bytes.HasSuffix(src[i:end-1], []byte("/")):
+			opaque = append(opaque, name)
+		}
+		i = end
+	}
+	return masked
+}
+
+// tagName extracts the (lowercased) element name from tag, a "<...>" span,
+// and reports whether it is a closing tag ("</name>").
+func tagName(tag []byte) (name string, closing bool) {
+	i := 1
+	if i < len(tag) && tag[i] == '/' {
+		closing = true
+		i++
+	}
+	start := i
+	for i < len(tag) && isNameByte(tag[i]) {
+		i++
+	}
+	return string(bytes.ToLower(tag[start:i])), closing
+}
+
+func isNameByte(c byte) bool {
+	return c == '-' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,217 @@
+// Copyright (c) 2015, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package xurls
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// FindInMarkdown returns the matches of re found in src, skipping fenced and
+// indented code blocks, inline code spans, the destination of existing
+// Markdown links and autolinks, and reference-style link definitions, so
+// that text already wrapped by the author is not relinked. Byte offsets in
+// the returned Matches refer to src. This uses a small hand-rolled scanner
+// rather than a full CommonMark parser, so it can be fooled by sufficiently
+// exotic Markdown.
+func FindInMarkdown(src []byte, re *regexp.Regexp) []Match {
+	return findMasked(src, re, maskMarkdown(src))
+}
+
+// maskMarkdown returns a slice parallel to src where masked[i] is true if
+// src[i] falls inside a region that should not be autolinked.
+func maskMarkdown(src []byte) []bool {
+	masked := make([]bool, len(src))
+	mark := func(lo, hi int) {
+		for ; lo < hi; lo++ {
+			masked[lo] = true
+		}
+	}
+
+	var fence []byte // non-nil while inside an open fenced code block
+	for pos := 0; pos < len(src); {
+		lineEnd := bytes.IndexByte(src[pos:], '\n')
+		if lineEnd < 0 {
+			lineEnd = len(src)
+		} else {
+			lineEnd += pos + 1
+		}
+		line := src[pos:lineEnd]
+		trimmed := bytes.TrimLeft(line, " \t")
+		indent := len(line) - len(trimmed)
+		content := bytes.TrimRight(trimmed, "\r\n")
+
+		switch {
+		case fence != nil:
+			mark(pos, lineEnd)
+			if indent < 4 && bytes.HasPrefix(content, fence) && isRunOf(content, fence[0]) {
+				fence = nil
+			}
+		case indent < 4 && isFenceStart(content):
+			mark(pos, lineEnd)
+			fence = content[:fenceLen(content)]
+		case indent >= 4:
+			mark(pos, lineEnd)
+		case indent < 4 && isRefDefinitionStart(content):
+			mark(pos, lineEnd)
+		default:
+			maskInlineMarkdown(line, pos, masked)
+		}
+		pos = lineEnd
+	}
+	return masked
+}
+
+// isFenceStart reports whether line opens a fenced code block: a run of at
+// least three backticks or tildes, optionally followed by an info string
+// such as "go". A backtick fence's info string may not contain backticks.
+func isFenceStart(line []byte) bool {
+	if len(line) < 3 || (line[0] != '`' && line[0] != '~') {
+		return false
+	}
+	n := fenceLen(line)
+	return n >= 3 && (line[0] == '~' || bytes.IndexByte(line[n:], '`') < 0)
+}
+
+func fenceLen(line []byte) int {
+	n := 0
+	for n < len(line) && line[n] == line[0] {
+		n++
+	}
+	return n
+}
+
+// isRefDefinitionStart reports whether content, a single trimmed line, opens
+// a Markdown reference-style link definition ("[label]: destination ..."),
+// so the whole line can be masked and its destination not treated as a
+// fresh match.
+func isRefDefinitionStart(content []byte) bool {
+	if len(content) == 0 || content[0] != '[' {
+		return false
+	}
+	end, ok := matchBracket(content, 0, '[', ']')
+	if !ok || end+1 >= len(content) || content[end+1] != ':' {
+		return false
+	}
+	return len(bytes.TrimSpace(content[1:end])) > 0
+}
+
+func isRunOf(b []byte, c byte) bool {
+	for _, x := range b {
+		if x != c {
+			return false
+		}
+	}
+	return len(b) > 0
+}
+
+// maskInlineMarkdown masks inline code spans and the destination of links
+// and autolinks found within line, a single line of non-code Markdown
+// starting at offset base within the original source.
+func maskInlineMarkdown(line []byte, base int, masked []bool) {
+	mark := func(lo, hi int) {
+		for ; lo < hi; lo++ {
+			masked[base+lo] = true
+		}
+	}
+
+	for i := 0; i < len(line); {
+		switch c := line[i]; {
+		case c == '`':
+			n := 0
+			for i+n < len(line) && line[i+n] == '`' {
+				n++
+			}
+			run := line[i : i+n]
+			if end := bytes.Index(line[i+n:], run); end >= 0 {
+				mark(i, i+n+end+n)
+				i += n + end + n
+				continue
+			}
+			i += n
+
+		case c == '<':
+			if end := bytes.IndexByte(line[i:], '>'); end >= 0 && looksLikeAutolink(line[i+1:i+end]) {
+				mark(i, i+end+1)
+				i += end + 1
+				continue
+			}
+			i++
+
+		case c == '[':
+			if textEnd, ok := matchBracket(line, i, '[', ']'); ok {
+				j := textEnd + 1
+				switch {
+				case j < len(line) && line[j] == '(':
+					if destEnd, ok := matchBracket(line, j, '(', ')'); ok {
+						mark(j, destEnd+1)
+						i = destEnd + 1
+						continue
+					}
+				case j < len(line) && line[j] == '[':
+					if refEnd, ok := matchBracket(line, j, '[', ']'); ok {
+						i = refEnd + 1
+						continue
+					}
+				}
+			}
+			i++
+
+		default:
+			i++
+		}
+	}
+}
+
+// looksLikeAutolink reports whether s, the content of a "<...>" span, is a
+// Markdown autolink target: a known scheme followed by "://" or ":" with no
+// interior whitespace.
+func looksLikeAutolink(s []byte) bool {
+	if bytes.ContainsAny(s, " \t<>") {
+		return false
+	}
+	_, _, ok := SplitScheme(string(s))
+	return ok
+}
+
+// matchBracket finds the index of the closing bracket matching the opening
+// bracket at line[open], accounting for nesting, returning ok=false if it is
+// never closed on this line.
+func matchBracket(line []byte, open int, openB, closeB byte) (close int, ok bool) {
+	depth := 0
+	for i := open; i < len(line); i++ {
+		switch line[i] {
+		case openB:
+			depth++
+		case closeB:
+			depth--
+			if depth == 0 {
+				return i, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func findMasked(src []byte, re *regexp.Regexp, masked []bool) []Match {
+	var matches []Match
+	segStart := -1
+	for i := 0; i <= len(src); i++ {
+		if i < len(src) && !masked[i] {
+			if segStart < 0 {
+				segStart = i
+			}
+			continue
+		}
+		if segStart >= 0 {
+			seg := src[segStart:i]
+			for _, idx := range re.FindAllIndex(seg, -1) {
+				start, end := segStart+idx[0], segStart+idx[1]
+				matches = append(matches, newMatch(string(src[start:end]), start, end))
+			}
+			segStart = -1
+		}
+	}
+	return matches
+}
@@ -0,0 +1,294 @@
+// Copyright (c) 2015, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package xurls
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Match is a single URL or email address found by FindAll or FindReader,
+// broken down into the parts that make it up. Fields that do not apply to a
+// particular match, such as Userinfo on a bare domain or TLD on an IP
+// address, are left as the zero value.
+type Match struct {
+	// Text is the full matched substring, and Start/End are its byte
+	// offsets within the text or reader that was searched.
+	Text       string
+	Start, End int
+
+	Scheme   string
+	Userinfo string
+	Host     string
+	Port     string
+	Path     string
+	Query    string
+	Fragment string
+
+	// IsEmail is true if Text is an email address rather than a URL, in
+	// which case Host holds the domain following the "@".
+	IsEmail bool
+	// IsIP is true if Host is an IPv4 or IPv6 address rather than a
+	// domain name.
+	IsIP bool
+	// TLD is the last label of Host, if Host is a domain name of more
+	// than one label; it is empty for a single-label host such as
+	// "localhost".
+	TLD string
+}
+
+// FindAll returns every match of re within text, classified into its
+// constituent parts. re should be the result of Strict, Relaxed,
+// StrictMatchingScheme or a Matcher built with NewMatcher. The fields past
+// Text, Start and End are parsed from the matched text alone, whatever
+// regexp produced it.
+func FindAll(re *regexp.Regexp, text string) []Match {
+	idxs := re.FindAllStringIndex(text, -1)
+	if idxs == nil {
+		return nil
+	}
+	matches := make([]Match, len(idxs))
+	for i, idx := range idxs {
+		matches[i] = newMatch(text[idx[0]:idx[1]], idx[0], idx[1])
+	}
+	return matches
+}
+
+// defaultReaderWindow is the size, in bytes, of the sliding buffer
+// FindReader keeps in memory: large enough to hold any plausible URL, so a
+// match straddling two reads of r is never split and lost.
+const defaultReaderWindow = 8 * 1024
+
+// ErrMatchTooLong is yielded by FindReader and FindReaderWindow in place of
+// a Match when a single match grows to at least the configured window size
+// without terminating. Retry with FindReaderWindow and a larger window if
+// the input is expected to contain matches that long.
+var ErrMatchTooLong = errors.New("xurls: match at or beyond the reader window size")
+
+// FindReader returns an iterator over every match of re found in r, scanning
+// through a sliding buffer of defaultReaderWindow bytes rather than reading
+// all of r into memory; see FindReaderWindow to change the buffer size and
+// FindReaderFunc for a callback form that can stop the scan early. The
+// second value yielded on each iteration is a read error from r (or
+// ErrMatchTooLong), if any; no further matches follow it.
+func FindReader(re *regexp.Regexp, r io.Reader) iter.Seq2[Match, error] {
+	return FindReaderWindow(re, r, defaultReaderWindow)
+}
+
+// FindReaderWindow is like FindReader, but scans r through a sliding buffer
+// of the given size instead of defaultReaderWindow. window should be at
+// least as large as the longest match expected to be found; a match that
+// reaches window bytes without terminating is reported as ErrMatchTooLong
+// rather than buffered indefinitely.
+func FindReaderWindow(re *regexp.Regexp, r io.Reader, window int) iter.Seq2[Match, error] {
+	if window <= 0 {
+		window = defaultReaderWindow
+	}
+	return func(yield func(Match, error) bool) {
+		buf := make([]byte, 0, window*2)
+		var base int64 // offset of buf[0] within r
+		eof := false
+
+		for {
+			if !eof && len(buf) < window*2 {
+				chunk := make([]byte, window)
+				n, err := r.Read(chunk)
+				buf = append(buf, chunk[:n]...)
+				if err != nil {
+					if err != io.EOF {
+						yield(Match{}, err)
+						return
+					}
+					eof = true
+				}
+				if !eof {
+					continue
+				}
+			}
+
+			// A match might still grow with more input unless some byte
+			// between its end and the end of buf can never be part of a
+			// match (whitespace, or one of "<>^`|), so it is not yet safe
+			// to report unless we have reached EOF; such a match blocks us
+			// from discarding the buffer past its start until it is
+			// resolved one way or other.
+			idxs := re.FindAllStringIndex(string(buf), -1)
+			advanced := 0
+			pendingStart := len(buf)
+			for _, idx := range idxs {
+				if !eof && !strings.ContainsAny(string(buf[idx[1]:]), " \t\r\n\"<>^`|") {
+					pendingStart = idx[0]
+					break
+				}
+				text := string(buf[idx[0]:idx[1]])
+				if !yield(newMatch(text, int(base)+idx[0], int(base)+idx[1]), nil) {
+					return
+				}
+				advanced = idx[1]
+			}
+
+			if !eof && pendingStart < len(buf) && len(buf)-pendingStart >= window {
+				yield(Match{}, fmt.Errorf("%w: started at offset %d", ErrMatchTooLong, base+int64(pendingStart)))
+				return
+			}
+
+			if eof {
+				return
+			}
+
+			// Bytes before pendingStart-window can hold no match we have
+			// not already reported: any match starting there would fit
+			// within window bytes of already-available data and so would
+			// have been found above. Discard them to bound buf's growth
+			// even when nothing has matched yet.
+			discardable := pendingStart - window
+			if discardable > advanced {
+				advanced = discardable
+			}
+			if advanced > 0 {
+				base += int64(advanced)
+				buf = buf[advanced:]
+			}
+		}
+	}
+}
+
+// FindReaderFunc calls f with every match of re found in r, in the style of
+// FindReader, stopping early if f returns false. It returns any read error
+// encountered from r.
+func FindReaderFunc(re *regexp.Regexp, r io.Reader, f func(Match) bool) error {
+	for m, err := range FindReader(re, r) {
+		if err != nil {
+			return err
+		}
+		if !f(m) {
+			return nil
+		}
+	}
+	return nil
+}
+
+func newMatch(text string, start, end int) Match {
+	m := Match{Text: text, Start: start, End: end}
+
+	if scheme, rest, noAuthoritySep, ok := cutKnownScheme(text); ok {
+		m.Scheme = scheme
+		if noAuthoritySep && !looksLikeAuthority(rest) {
+			m.Path, m.Query, m.Fragment = splitOpaque(rest)
+		} else {
+			m.Userinfo, m.Host, m.Port, m.Path, m.Query, m.Fragment = splitAuthority(rest)
+		}
+	} else if at := strings.LastIndexByte(text, '@'); at >= 0 {
+		m.IsEmail = true
+		m.Host = text[at+1:]
+	} else {
+		m.Userinfo, m.Host, m.Port, m.Path, m.Query, m.Fragment = splitAuthority(text)
+	}
+
+	if m.Host == "" {
+		return m
+	}
+	if ip := net.ParseIP(strings.Trim(m.Host, "[]")); ip != nil {
+		m.IsIP = true
+	} else if i := strings.LastIndexByte(m.Host, '.'); i >= 0 {
+		m.TLD = m.Host[i+1:]
+	}
+	return m
+}
+
+// looksLikeAuthority reports whether rest, the opaque part of a match whose
+// scheme uses a bare ":" separator (see SchemesNoAuthority), actually has an
+// authority to parse: either an explicit "//", as in "file:", or an "@"
+// before any "/", "?" or "#", as in the addr-spec carried by "mailto:" and
+// "xmpp:" opaque parts. Other SchemesNoAuthority opaque parts, such as
+// "tel:" or "bitcoin:", have no host at all, and splitAuthority must not be
+// run on them.
+func looksLikeAuthority(rest string) bool {
+	if strings.HasPrefix(rest, "//") {
+		return true
+	}
+	head := rest
+	if i := strings.IndexAny(rest, "/?#"); i >= 0 {
+		head = rest[:i]
+	}
+	return strings.ContainsRune(head, '@')
+}
+
+// splitOpaque pulls the query and fragment off rest, an opaque (non-
+// authority) scheme part such as a "tel:" or "bitcoin:" payload, leaving
+// the rest as path.
+func splitOpaque(rest string) (path, query, fragment string) {
+	path = rest
+	if i := strings.IndexByte(path, '#'); i >= 0 {
+		path, fragment = path[:i], path[i+1:]
+	}
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path, query = path[:i], path[i+1:]
+	}
+	return path, query, fragment
+}
+
+// cutKnownScheme reports whether text begins with one of Schemes,
+// SchemesUnofficial or SchemesNoAuthority, returning the scheme (lowercased)
+// and the remainder of text after its separator ("://" or ":").
+// noAuthoritySep is true when that separator was the bare ":" used by
+// SchemesNoAuthority, as opposed to "://"; rest is then an opaque part that
+// may or may not itself carry an authority (see looksLikeAuthority).
+func cutKnownScheme(text string) (scheme, rest string, noAuthoritySep, ok bool) {
+	i := strings.IndexByte(text, ':')
+	if i <= 0 {
+		return "", "", false, false
+	}
+	candidate := strings.ToLower(text[:i])
+	if inSortedSlice(SchemesNoAuthority, candidate) {
+		return candidate, text[i+1:], true, true
+	}
+	if !strings.HasPrefix(text[i:], "://") {
+		return "", "", false, false
+	}
+	if inSortedSlice(Schemes, candidate) || inSortedSlice(SchemesUnofficial, candidate) {
+		return candidate, text[i+3:], false, true
+	}
+	return "", "", false, false
+}
+
+func inSortedSlice(sorted []string, s string) bool {
+	i := sort.SearchStrings(sorted, s)
+	return i < len(sorted) && sorted[i] == s
+}
+
+// splitAuthority pulls apart the userinfo, host, port, path, query and
+// fragment of rest, the portion of a match following its scheme (or the
+// whole match, for a scheme-less web URL).
+func splitAuthority(rest string) (userinfo, host, port, path, query, fragment string) {
+	rest = strings.TrimPrefix(rest, "//")
+
+	authority := rest
+	if i := strings.IndexAny(rest, "/?#"); i >= 0 {
+		authority, path = rest[:i], rest[i:]
+	}
+
+	if i := strings.LastIndexByte(authority, '@'); i >= 0 {
+		userinfo, authority = authority[:i], authority[i+1:]
+	}
+
+	host = authority
+	if i := strings.LastIndexByte(authority, ':'); i >= 0 && !strings.Contains(authority[i:], "]") {
+		host, port = authority[:i], authority[i+1:]
+	}
+
+	if i := strings.IndexByte(path, '#'); i >= 0 {
+		path, fragment = path[:i], path[i+1:]
+	}
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path, query = path[:i], path[i+1:]
+	}
+	return userinfo, host, port, path, query, fragment
+}
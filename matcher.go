@@ -0,0 +1,196 @@
+// Copyright (c) 2015, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package xurls
+
+import (
+	"fmt"
+	"regexp"
+	"unicode/utf8"
+)
+
+// Matcher builds a url-matching regexp from a set of options, for callers
+// who need more control than the Strict and Relaxed presets allow. Build
+// one with NewMatcher, tune it with its With*/Allow*/Require* methods, and
+// call Compile to obtain the regexp. A Matcher is not safe for concurrent
+// use while it is being configured, but the *regexp.Regexp returned by
+// Compile is.
+type Matcher struct {
+	schemes       []string
+	schemesNoAuth []string
+	tlds          []string
+
+	allowEmails          bool
+	requireScheme        bool
+	allowIPv6            bool
+	allowPrivateUseChars bool
+	maxLength            int
+}
+
+// NewMatcher returns a Matcher preconfigured like Relaxed: every known
+// scheme, every known TLD, emails and IPv6 allowed, and no scheme required.
+func NewMatcher() *Matcher {
+	return &Matcher{
+		schemes:              append(append([]string{}, Schemes...), SchemesUnofficial...),
+		schemesNoAuth:        append([]string{}, SchemesNoAuthority...),
+		allowEmails:          true,
+		allowIPv6:            true,
+		allowPrivateUseChars: true,
+	}
+}
+
+// WithSchemes restricts the schemes that may precede "://" to exactly those
+// given, in place of the Schemes and SchemesUnofficial defaults. It does
+// not affect the SchemesNoAuthority list; see WithoutSchemes for that.
+func (m *Matcher) WithSchemes(schemes ...string) *Matcher {
+	m.schemes = append([]string{}, schemes...)
+	return m
+}
+
+// WithoutSchemes removes the given schemes, whether they require an
+// authority or not, from the set the Matcher will accept.
+func (m *Matcher) WithoutSchemes(schemes ...string) *Matcher {
+	remove := make(map[string]bool, len(schemes))
+	for _, s := range schemes {
+		remove[s] = true
+	}
+	m.schemes = withoutNames(m.schemes, remove)
+	m.schemesNoAuth = withoutNames(m.schemesNoAuth, remove)
+	return m
+}
+
+func withoutNames(names []string, remove map[string]bool) []string {
+	kept := make([]string, 0, len(names))
+	for _, n := range names {
+		if !remove[n] {
+			kept = append(kept, n)
+		}
+	}
+	return kept
+}
+
+// WithTLDs restricts the top-level domains accepted by a scheme-less web
+// URL or email match to exactly those given, in place of the full TLDs and
+// PseudoTLDs lists. It has no effect when RequireScheme(true) is set, since
+// then no TLD is ever consulted.
+func (m *Matcher) WithTLDs(tlds ...string) *Matcher {
+	m.tlds = append([]string{}, tlds...)
+	return m
+}
+
+// AllowEmails controls whether a scheme-less match may be an email address.
+// It defaults to true, as in Relaxed.
+func (m *Matcher) AllowEmails(allow bool) *Matcher {
+	m.allowEmails = allow
+	return m
+}
+
+// RequireScheme controls whether a match must have a scheme, as in Strict,
+// rather than allowing the scheme-less web URL and email forms of Relaxed.
+func (m *Matcher) RequireScheme(require bool) *Matcher {
+	m.requireScheme = require
+	return m
+}
+
+// AllowIPv6 controls whether a scheme-less host may be an IPv6 address. It
+// defaults to true.
+func (m *Matcher) AllowIPv6(allow bool) *Matcher {
+	m.allowIPv6 = allow
+	return m
+}
+
+// AllowPrivateUseChars controls whether the path, query and fragment of a
+// match may contain the Unicode private-use areas. It defaults to true.
+func (m *Matcher) AllowPrivateUseChars(allow bool) *Matcher {
+	m.allowPrivateUseChars = allow
+	return m
+}
+
+// WithMaxLength caps the number of characters a match's path, query and
+// fragment may span. A value of 0, the default, leaves it unbounded. Setting
+// a cap drops the handling described at pathCont: well-balanced
+// parens/brackets/braces are no longer matched, and a match may end in
+// punctuation, since those forms cannot be reconciled with a hard limit.
+// Compile returns an error for n above 1000, the largest repeat count the
+// regexp package accepts.
+func (m *Matcher) WithMaxLength(n int) *Matcher {
+	m.maxLength = n
+	return m
+}
+
+// Compile assembles and compiles the regexp described by m. It may be
+// called more than once, including after further With*/Allow* calls.
+func (m *Matcher) Compile() (*regexp.Regexp, error) {
+	re, err := regexp.Compile(m.exp())
+	if err != nil {
+		return nil, err
+	}
+	re.Longest()
+	return re, nil
+}
+
+func (m *Matcher) exp() string {
+	schemes := `(?:(?:` + anyOf(m.schemes...) + `)://|` + anyOf(m.schemesNoAuth...) + `:)`
+	pathCont := m.pathCont()
+	strict := `(?i)` + schemes + `(?-i)` + pathCont
+	if m.requireScheme {
+		return strict
+	}
+
+	ipAddr := ipv4Addr
+	if m.allowIPv6 {
+		ipAddr = `(?:` + ipv4Addr + `|` + ipv6Addr + `)`
+	}
+	site := domain + m.tldsExp()
+	hostName := `(?:` + site + `|` + ipAddr + `)`
+	webURL := hostName + port + `(?:/` + pathCont + `|/)?`
+
+	exp := strict + `|` + webURL
+	if m.allowEmails {
+		exp += `|` + `[a-zA-Z0-9._%\-+]+@` + site
+	}
+	return exp
+}
+
+func (m *Matcher) pathCont() string {
+	midI := `/?#\\` + midIPathSegmentChar
+	endI := `/#` + endIPathSegmentChar
+	if m.allowPrivateUseChars {
+		midI += iPrivateChar
+		endI += iPrivateChar
+	}
+
+	if m.maxLength > 0 {
+		// wellParen/wellBrack/wellBrace below nest their own unbounded
+		// repetition, so a length cap can only be made to hold by dropping
+		// them in favor of one flat, directly bounded character class.
+		return fmt.Sprintf(`[%s%s]{1,%d}`, midI, endI, m.maxLength)
+	}
+
+	wellParen := `\((?:[` + midI + `]|\([` + midI + `]*\))*\)`
+	wellBrack := `\[(?:[` + midI + `]|\[[` + midI + `]*\])*\]`
+	wellBrace := `\{(?:[` + midI + `]|\{[` + midI + `]*\})*\}`
+	wellAll := wellParen + `|` + wellBrack + `|` + wellBrace
+	return `(?:[` + midI + `]*(?:` + wellAll + `|[` + endI + `]))+`
+}
+
+func (m *Matcher) tldsExp() string {
+	if m.tlds != nil {
+		return `(?i)(?:` + anyOf(m.tlds...) + `\b)(?-i)`
+	}
+
+	var asciiTLDs, unicodeTLDs []string
+	for i, tld := range TLDs {
+		if tld[0] >= utf8.RuneSelf {
+			asciiTLDs = TLDs[:i:i]
+			unicodeTLDs = TLDs[i:]
+			break
+		}
+	}
+	punycode := `xn--[a-z0-9-]+`
+
+	// Use \b to make sure ASCII TLDs are immediately followed by a word break.
+	// We can't do that with unicode TLDs, as they don't see following
+	// whitespace as a word break.
+	return `(?i)(?:` + punycode + `|` + anyOf(append(asciiTLDs, PseudoTLDs...)...) + `\b|` + anyOf(unicodeTLDs...) + `)(?-i)`
+}
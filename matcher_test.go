@@ -0,0 +1,24 @@
+// Copyright (c) 2015, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package xurls
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatcherWithMaxLength(t *testing.T) {
+	const max = 5
+	re, err := NewMatcher().RequireScheme(true).WithMaxLength(max).Compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const scheme = "https://"
+	text := scheme + strings.Repeat("a", 5000)
+	got := re.FindString(text)
+	if want := len(scheme) + max; len(got) > want {
+		t.Fatalf("match was %d bytes long, want at most %d: %q", len(got), want, got)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2015, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package xurls
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DangerousSchemes is a sorted list of schemes that are well-known vectors
+// for XSS or data exfiltration when a match is turned into a clickable
+// link, such as "javascript" running script in the referring page or "file"
+// reading the local filesystem. Safe and SafeMatchingScheme never match
+// them; callers wiring xurls into an HTML sanitizer like bluemonday can
+// reuse this list directly.
+var DangerousSchemes = func() []string {
+	schemes := []string{
+		`chrome`,
+		`chrome-extension`,
+		`data`,
+		`file`,
+		`hxxp`,
+		`hxxps`,
+		`jar`,
+		`javascript`,
+		`livescript`,
+		`moz-extension`,
+		`vbscript`,
+		`view-source`,
+	}
+	for _, s := range Schemes {
+		if strings.HasPrefix(s, "ms-") {
+			schemes = append(schemes, s)
+		}
+	}
+	sort.Strings(schemes)
+	return schemes
+}()
+
+var (
+	safeRe   *regexp.Regexp
+	safeInit sync.Once
+)
+
+func withoutDangerousSchemes(schemes []string) []string {
+	kept := make([]string, 0, len(schemes))
+	for _, s := range schemes {
+		if !inSortedSlice(DangerousSchemes, s) {
+			kept = append(kept, s)
+		}
+	}
+	return kept
+}
+
+func safeExp() string {
+	schemes := `(?:(?:` + anyOf(withoutDangerousSchemes(Schemes)...) + `|` +
+		anyOf(withoutDangerousSchemes(SchemesUnofficial)...) + `)://|` +
+		anyOf(withoutDangerousSchemes(SchemesNoAuthority)...) + `:)`
+	return `(?i)` + schemes + `(?-i)` + pathCont
+}
+
+// Safe produces a regexp that matches like Strict, except that it never
+// matches a URL whose scheme is in DangerousSchemes.
+func Safe() *regexp.Regexp {
+	safeInit.Do(func() {
+		safeRe = regexp.MustCompile(safeExp())
+		safeRe.Longest()
+	})
+	return safeRe
+}
+
+// SafeMatchingScheme is like StrictMatchingScheme, but additionally rejects
+// exp, returning an error instead of a regexp that would be unsafe to
+// autolink, if exp matches the whole of "s://" or "s:" (ignoring case) for
+// any scheme s in DangerousSchemes. This catches mistakes such as
+// SafeMatchingScheme(AnyScheme), which would otherwise silently accept
+// "javascript://" and friends. An exp that only matches a dangerous scheme
+// together with further text after its ":" is not rejected.
+func SafeMatchingScheme(exp string) (*regexp.Regexp, error) {
+	probe, err := regexp.Compile(`(?i)^(?:` + exp + `)$`)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range DangerousSchemes {
+		if probe.MatchString(s+"://") || probe.MatchString(s+":") {
+			return nil, fmt.Errorf("xurls: scheme expression %q matches dangerous scheme %q", exp, s)
+		}
+	}
+	return StrictMatchingScheme(exp)
+}
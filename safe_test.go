@@ -0,0 +1,50 @@
+// Copyright (c) 2015, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package xurls
+
+import "testing"
+
+func TestSafe(t *testing.T) {
+	cases := []struct {
+		text string
+		want string
+	}{
+		{"visit https://example.com today", "https://example.com"},
+		{"run javascript:alert(1) now", ""},
+		{"data:text/html,<script>evil</script>", ""},
+		{"file:///etc/passwd", ""},
+		{"tel:+15551234567", "tel:+15551234567"},
+	}
+	for _, c := range cases {
+		got := Safe().FindString(c.text)
+		if got != c.want {
+			t.Errorf("Safe().FindString(%q) = %q, want %q", c.text, got, c.want)
+		}
+	}
+}
+
+func TestSafeMatchingScheme(t *testing.T) {
+	if _, err := SafeMatchingScheme(AnyScheme); err == nil {
+		t.Fatal("SafeMatchingScheme(AnyScheme) succeeded, want an error rejecting dangerous schemes")
+	}
+
+	re, err := SafeMatchingScheme(`https?://`)
+	if err != nil {
+		t.Fatalf("SafeMatchingScheme(%q) failed: %v", `https?://`, err)
+	}
+	if got := re.FindString("visit https://example.com today"); got != "https://example.com" {
+		t.Errorf("got %q, want %q", got, "https://example.com")
+	}
+}
+
+func TestDangerousSchemes(t *testing.T) {
+	for _, s := range []string{"javascript", "data", "file", "vbscript", "ms-settings"} {
+		if !inSortedSlice(DangerousSchemes, s) {
+			t.Errorf("DangerousSchemes does not contain %q", s)
+		}
+	}
+	if inSortedSlice(DangerousSchemes, "https") {
+		t.Error("DangerousSchemes unexpectedly contains https")
+	}
+}
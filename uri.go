@@ -0,0 +1,157 @@
+// Copyright (c) 2015, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package xurls
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SplitScheme splits s into its scheme and the remainder following the
+// first ":", if that scheme is one xurls knows about -- a member of
+// Schemes, SchemesUnofficial or SchemesNoAuthority. scheme is returned
+// lowercased; rest keeps s's original casing. ok is false, with scheme and
+// rest empty, if s does not begin with a recognized scheme.
+func SplitScheme(s string) (scheme, rest string, ok bool) {
+	i := strings.IndexByte(s, ':')
+	if i <= 0 {
+		return "", "", false
+	}
+	candidate := strings.ToLower(s[:i])
+	if !isKnownScheme(candidate) {
+		return "", "", false
+	}
+	return candidate, s[i+1:], true
+}
+
+func isKnownScheme(scheme string) bool {
+	return inSortedSlice(Schemes, scheme) ||
+		inSortedSlice(SchemesUnofficial, scheme) ||
+		inSortedSlice(SchemesNoAuthority, scheme)
+}
+
+// IsURI reports whether s begins like an absolute URI: a recognized scheme
+// per SplitScheme, followed either by "//" (for schemes outside
+// SchemesNoAuthority) or directly by a non-empty opaque part. Only that
+// prefix is checked; the rest of s is not validated, so "http://" and
+// "http://a b" are both reported as URIs.
+func IsURI(s string) bool {
+	scheme, rest, ok := SplitScheme(s)
+	if !ok || rest == "" {
+		return false
+	}
+	if inSortedSlice(SchemesNoAuthority, scheme) {
+		return true
+	}
+	return strings.HasPrefix(rest, "//")
+}
+
+// defaultPort maps schemes to the port implied when none is given, so
+// Normalize can drop a redundant ":80" or ":443".
+var defaultPort = map[string]string{
+	"ftp":   "21",
+	"http":  "80",
+	"https": "443",
+	"ws":    "80",
+	"wss":   "443",
+}
+
+// Normalize returns s with its scheme and host lowercased, "."/".." path
+// segments collapsed, a redundant default port dropped, and any bytes
+// Escape would encode percent-encoded. s is returned unchanged if it does
+// not begin with a known scheme.
+func Normalize(s string) string {
+	scheme, rest, ok := SplitScheme(s)
+	if !ok {
+		return s
+	}
+
+	var b strings.Builder
+	b.WriteString(scheme)
+	b.WriteByte(':')
+
+	if !strings.HasPrefix(rest, "//") {
+		b.WriteString(Escape(rest))
+		return b.String()
+	}
+	b.WriteString("//")
+	rest = rest[2:]
+
+	authority, path := rest, ""
+	if i := strings.IndexAny(rest, "/?#"); i >= 0 {
+		authority, path = rest[:i], rest[i:]
+	}
+
+	userinfo, host := "", authority
+	if i := strings.LastIndexByte(authority, '@'); i >= 0 {
+		userinfo, host = authority[:i+1], authority[i+1:]
+	}
+	b.WriteString(Escape(userinfo))
+
+	hostPort, port := host, ""
+	if i := strings.LastIndexByte(host, ':'); i >= 0 && !strings.Contains(host[i:], "]") {
+		hostPort, port = host[:i], host[i+1:]
+	}
+	b.WriteString(Escape(strings.ToLower(hostPort)))
+	if port != "" && port != defaultPort[scheme] {
+		b.WriteByte(':')
+		b.WriteString(port)
+	}
+
+	b.WriteString(Escape(collapseDotSegments(path)))
+	return b.String()
+}
+
+// collapseDotSegments removes "." and ".." segments from the path component
+// of path, leaving any query or fragment suffix untouched.
+func collapseDotSegments(path string) string {
+	suffix := ""
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path, suffix = path[:i], path[i:]
+	}
+
+	segments := strings.Split(path, "/")
+	kept := segments[:0]
+	for _, seg := range segments {
+		switch seg {
+		case ".":
+		case "..":
+			if len(kept) > 1 {
+				kept = kept[:len(kept)-1]
+			}
+		default:
+			kept = append(kept, seg)
+		}
+	}
+	return strings.Join(kept, "/") + suffix
+}
+
+// escapeChars are bytes that are technically allowed in a URI by RFC 3987
+// but unwise to leave unescaped once the URI is embedded in contexts like
+// HTML attributes, Markdown links or a shell command line.
+const escapeChars = `<>"{}|\^` + "`"
+
+// Escape percent-encodes whitespace and escapeChars found in an existing
+// URI, without double-encoding a "%" that already introduces a valid
+// percent-escape.
+func Escape(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]):
+			b.WriteByte(c)
+		case c <= ' ' || strings.IndexByte(escapeChars, c) >= 0:
+			fmt.Fprintf(&b, "%%%02X", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func isHex(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
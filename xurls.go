@@ -8,7 +8,6 @@ import (
 	"regexp"
 	"strings"
 	"sync"
-	"unicode/utf8"
 )
 
 //go:generate go run ./generate/tldsgen
@@ -108,50 +107,32 @@ func anyOf(strs ...string) string {
 	return b.String()
 }
 
-func strictExp() string {
-	schemes := `(?:(?:` + anyOf(Schemes...) + `|` + anyOf(SchemesUnofficial...) + `)://|` + anyOf(SchemesNoAuthority...) + `:)`
-	return `(?i)` + schemes + `(?-i)` + pathCont
-}
-
-func relaxedExp() string {
-	var asciiTLDs, unicodeTLDs []string
-	for i, tld := range TLDs {
-		if tld[0] >= utf8.RuneSelf {
-			asciiTLDs = TLDs[:i:i]
-			unicodeTLDs = TLDs[i:]
-			break
-		}
-	}
-	punycode := `xn--[a-z0-9-]+`
-
-	// Use \b to make sure ASCII TLDs are immediately followed by a word break.
-	// We can't do that with unicode TLDs, as they don't see following
-	// whitespace as a word break.
-	tlds := `(?i)(?:` + punycode + `|` + anyOf(append(asciiTLDs, PseudoTLDs...)...) + `\b|` + anyOf(unicodeTLDs...) + `)(?-i)`
-	site := domain + tlds
-
-	hostName := `(?:` + site + `|` + ipAddr + `)`
-	webURL := hostName + port + `(?:/` + pathCont + `|/)?`
-	email := `[a-zA-Z0-9._%\-+]+@` + site
-	return strictExp() + `|` + webURL + `|` + email
-}
-
 // Strict produces a regexp that matches any URL with a scheme in either the
-// Schemes or SchemesNoAuthority lists.
+// Schemes or SchemesNoAuthority lists. It is a thin wrapper around
+// NewMatcher; use NewMatcher directly to tune which schemes or TLDs it
+// accepts.
 func Strict() *regexp.Regexp {
 	strictInit.Do(func() {
-		strictRe = regexp.MustCompile(strictExp())
-		strictRe.Longest()
+		re, err := NewMatcher().RequireScheme(true).Compile()
+		if err != nil {
+			panic(err)
+		}
+		strictRe = re
 	})
 	return strictRe
 }
 
 // Relaxed produces a regexp that matches any URL matched by Strict, plus any
-// URL with no scheme or email address.
+// URL with no scheme or email address. It is a thin wrapper around
+// NewMatcher; use NewMatcher directly to tune which schemes or TLDs it
+// accepts.
 func Relaxed() *regexp.Regexp {
 	relaxedInit.Do(func() {
-		relaxedRe = regexp.MustCompile(relaxedExp())
-		relaxedRe.Longest()
+		re, err := NewMatcher().Compile()
+		if err != nil {
+			panic(err)
+		}
+		relaxedRe = re
 	})
 	return relaxedRe
 }